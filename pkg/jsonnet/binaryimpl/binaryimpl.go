@@ -0,0 +1,108 @@
+// Package binaryimpl implements jsonnet.Implementation by shelling
+// out to an external jsonnet binary, e.g. the reference C++
+// implementation, which can outperform go-jsonnet on very large
+// trees.
+package binaryimpl
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+)
+
+// Impl shells out to the jsonnet binary at the path given to New for
+// every evaluation.
+//
+// Native functions have no equivalent when evaluating out-of-process,
+// so NativeFunction is a no-op; callers relying on natives such as
+// parseYaml should use the go implementation instead.
+type Impl struct {
+	bin string
+
+	jpaths            []string
+	extVars, extCodes map[string]string
+	tlaVars, tlaCodes map[string]string
+}
+
+// New returns an Implementation that shells out to the jsonnet binary
+// at path.
+func New(path string) *Impl {
+	return &Impl{
+		bin:      path,
+		extVars:  map[string]string{},
+		extCodes: map[string]string{},
+		tlaVars:  map[string]string{},
+		tlaCodes: map[string]string{},
+	}
+}
+
+// EvaluateSnippet is not supported by the binary backend, which only
+// evaluates files; it returns an error.
+func (i *Impl) EvaluateSnippet(filename, snippet string) (string, error) {
+	return "", fmt.Errorf("binaryimpl: EvaluateSnippet unsupported, write %q to a file and use EvaluateFile", filename)
+}
+
+// EvaluateFile evaluates the jsonnet file at filename by invoking the
+// configured jsonnet binary.
+func (i *Impl) EvaluateFile(filename string) (string, error) {
+	args := i.args()
+	args = append(args, filename)
+
+	cmd := exec.Command(i.bin, args...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("%s: %w: %s", i.bin, err, stderr.String())
+	}
+
+	return stdout.String(), nil
+}
+
+// ExtVar binds an external string variable, passed as -V on the next
+// evaluation.
+func (i *Impl) ExtVar(key, value string) { i.extVars[key] = value }
+
+// ExtCode binds an external variable to a jsonnet expression, passed
+// as --ext-code.
+func (i *Impl) ExtCode(key, code string) { i.extCodes[key] = code }
+
+// TLAVar binds a top-level string argument, passed as -A.
+func (i *Impl) TLAVar(key, value string) { i.tlaVars[key] = value }
+
+// TLACode binds a top-level argument to a jsonnet expression, passed
+// as --tla-code.
+func (i *Impl) TLACode(key, code string) { i.tlaCodes[key] = code }
+
+// Importer sets the import search path, passed as repeated -J flags.
+func (i *Impl) Importer(paths []string) {
+	i.jpaths = paths
+}
+
+// NativeFunction is a no-op: the jsonnet binary has no way to call
+// back into Go.
+func (i *Impl) NativeFunction(name string, params []string, fn func(args []interface{}) (interface{}, error)) {
+}
+
+func (i *Impl) args() []string {
+	args := make([]string, 0, 2*(len(i.jpaths)+len(i.extVars)+len(i.extCodes)+len(i.tlaVars)+len(i.tlaCodes)))
+
+	for _, p := range i.jpaths {
+		args = append(args, "-J", p)
+	}
+	for k, v := range i.extVars {
+		args = append(args, "-V", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range i.extCodes {
+		args = append(args, "--ext-code", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range i.tlaVars {
+		args = append(args, "-A", fmt.Sprintf("%s=%s", k, v))
+	}
+	for k, v := range i.tlaCodes {
+		args = append(args, "--tla-code", fmt.Sprintf("%s=%s", k, v))
+	}
+
+	return args
+}
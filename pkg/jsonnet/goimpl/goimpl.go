@@ -0,0 +1,71 @@
+// Package goimpl implements jsonnet.Implementation on top of the
+// native github.com/google/go-jsonnet evaluator.
+package goimpl
+
+import (
+	jsonnet "github.com/google/go-jsonnet"
+	"github.com/google/go-jsonnet/ast"
+)
+
+// Impl wraps a *jsonnet.VM.
+type Impl struct {
+	vm       *jsonnet.VM
+	importer *jsonnet.FileImporter
+}
+
+// New returns an Implementation backed by github.com/google/go-jsonnet.
+func New() *Impl {
+	importer := &jsonnet.FileImporter{}
+	vm := jsonnet.MakeVM()
+	vm.Importer(importer)
+
+	return &Impl{vm: vm, importer: importer}
+}
+
+// EvaluateSnippet evaluates snippet as if it were read from filename.
+func (i *Impl) EvaluateSnippet(filename, snippet string) (string, error) {
+	return i.vm.EvaluateSnippet(filename, snippet)
+}
+
+// EvaluateFile evaluates the jsonnet file at filename.
+func (i *Impl) EvaluateFile(filename string) (string, error) {
+	return i.vm.EvaluateFile(filename)
+}
+
+// ExtVar binds an external string variable.
+func (i *Impl) ExtVar(key, value string) { i.vm.ExtVar(key, value) }
+
+// ExtCode binds an external variable to a jsonnet expression.
+func (i *Impl) ExtCode(key, code string) { i.vm.ExtCode(key, code) }
+
+// TLAVar binds a top-level string argument.
+func (i *Impl) TLAVar(key, value string) { i.vm.TLAVar(key, value) }
+
+// TLACode binds a top-level argument to a jsonnet expression.
+func (i *Impl) TLACode(key, code string) { i.vm.TLACode(key, code) }
+
+// Importer sets the import search path, replacing any previous value.
+func (i *Impl) Importer(paths []string) {
+	i.importer.JPaths = paths
+}
+
+// SetMaxStack sets the maximum jsonnet evaluation stack depth. It's
+// specific to go-jsonnet, so it isn't part of Implementation; callers
+// that need it type-assert to *Impl.
+func (i *Impl) SetMaxStack(n int) {
+	i.vm.MaxStack = n
+}
+
+// NativeFunction registers fn as a jsonnet native function named name.
+func (i *Impl) NativeFunction(name string, params []string, fn func(args []interface{}) (interface{}, error)) {
+	ids := make(ast.Identifiers, len(params))
+	for idx, p := range params {
+		ids[idx] = ast.Identifier(p)
+	}
+
+	i.vm.NativeFunction(&jsonnet.NativeFunction{
+		Name:   name,
+		Params: ids,
+		Func:   fn,
+	})
+}
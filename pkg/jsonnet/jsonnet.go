@@ -0,0 +1,43 @@
+// Package jsonnet abstracts jsonnet evaluation behind an
+// Implementation interface, so callers can swap the native Go
+// evaluator for an external jsonnet binary without changing call
+// sites.
+package jsonnet
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gouthamve/haiku/pkg/jsonnet/binaryimpl"
+	"github.com/gouthamve/haiku/pkg/jsonnet/goimpl"
+)
+
+// Implementation evaluates jsonnet. Methods mirror the subset of
+// *jsonnet.VM that templator needs: evaluation, ext/TLA var
+// injection, import path configuration and native functions.
+type Implementation interface {
+	EvaluateSnippet(filename, snippet string) (string, error)
+	EvaluateFile(filename string) (string, error)
+
+	ExtVar(key, value string)
+	ExtCode(key, code string)
+	TLAVar(key, value string)
+	TLACode(key, code string)
+
+	Importer(paths []string)
+	NativeFunction(name string, params []string, fn func(args []interface{}) (interface{}, error))
+}
+
+// New resolves sel to an Implementation. "" and "go" select the
+// native go-jsonnet implementation; "binary:<path>" shells out to the
+// jsonnet binary at <path>, which can be faster on very large trees.
+func New(sel string) (Implementation, error) {
+	switch {
+	case sel == "" || sel == "go":
+		return goimpl.New(), nil
+	case strings.HasPrefix(sel, "binary:"):
+		return binaryimpl.New(strings.TrimPrefix(sel, "binary:")), nil
+	default:
+		return nil, fmt.Errorf("unknown jsonnet implementation %q", sel)
+	}
+}
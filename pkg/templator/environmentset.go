@@ -0,0 +1,170 @@
+package templator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// EnvironmentSet evaluates a jsonnet entry point across every
+// environment under environments/ matching glob, in parallel, each
+// with its own JsonnetTemplator and its own VM, so there's no
+// importer cache reuse across environments.
+type EnvironmentSet struct {
+	glob string
+	opts []Opt
+}
+
+// NewEnvironmentSet returns an EnvironmentSet that discovers
+// environments under "environments/<glob>" (e.g. "*/*" for
+// "environments/<region>/<cluster>"). Only directories match; glob
+// is a filepath.Glob pattern, there's no label selector support yet.
+// opts are applied to every environment's JsonnetTemplator.
+func NewEnvironmentSet(glob string, opts ...Opt) *EnvironmentSet {
+	return &EnvironmentSet{glob: glob, opts: opts}
+}
+
+// EnvironmentError pairs an environment with the error encountered
+// evaluating it.
+type EnvironmentError struct {
+	Environment string
+	Err         error
+}
+
+func (e *EnvironmentError) Error() string {
+	return fmt.Sprintf("%s: %v", e.Environment, e.Err)
+}
+
+// MultiError aggregates the errors of a set of failed environments.
+type MultiError struct {
+	Errors []*EnvironmentError
+}
+
+func (m *MultiError) Error() string {
+	msgs := make([]string, len(m.Errors))
+	for i, err := range m.Errors {
+		msgs[i] = err.Error()
+	}
+	return fmt.Sprintf("%d environment(s) failed:\n%s", len(m.Errors), strings.Join(msgs, "\n"))
+}
+
+// Template evaluates every discovered environment in parallel and
+// returns the resulting objects keyed by environment path (relative
+// to environments/). Evaluation errors are aggregated rather than
+// short-circuiting the whole set: on partial failure, Template
+// returns both the objects that did evaluate and a *MultiError
+// describing the rest.
+func (es *EnvironmentSet) Template() (map[string][]*unstructured.Unstructured, error) {
+	envs, err := es.discover()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string][]*unstructured.Unstructured, len(envs))
+		errs    []*EnvironmentError
+	)
+
+	for _, env := range envs {
+		env := env
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			objs, err := templateEnvironment(env, es.opts)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs = append(errs, &EnvironmentError{Environment: env, Err: err})
+				return
+			}
+			results[env] = objs
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, &MultiError{Errors: errs}
+	}
+
+	return results, nil
+}
+
+func templateEnvironment(env string, opts []Opt) ([]*unstructured.Unstructured, error) {
+	metaOpt, cleanup, err := withMetadataImport(env)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	envOpts := append(append([]Opt{}, opts...), metaOpt)
+
+	jt, err := NewJsonnetTemplator(env, envOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return jt.Template()
+}
+
+// discover returns every subdirectory of environments/ matching glob,
+// relative to environments/. Matches that aren't directories (e.g. a
+// stray README) are skipped.
+func (es *EnvironmentSet) discover() ([]string, error) {
+	matches, err := filepath.Glob(filepath.Join("environments", es.glob))
+	if err != nil {
+		return nil, err
+	}
+
+	envs := make([]string, 0, len(matches))
+	for _, m := range matches {
+		info, err := os.Stat(m)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			continue
+		}
+
+		rel, err := filepath.Rel("environments", m)
+		if err != nil {
+			return nil, err
+		}
+		envs = append(envs, rel)
+	}
+
+	return envs, nil
+}
+
+// withMetadataImport makes "import \"metadata.libsonnet\"" resolve to
+// a synthetic file carrying env's cluster name and path, without it
+// needing to exist on disk. It writes the synthetic file to a temp
+// directory prepended to the jsonnet import path, ahead of any real
+// .metadata directory, and returns a cleanup func that removes it.
+func withMetadataImport(env string) (Opt, func(), error) {
+	dir, err := ioutil.TempDir("", "haiku-metadata-")
+	if err != nil {
+		return nil, nil, err
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	content := fmt.Sprintf("{ cluster: %q, path: %q }", filepath.Base(env), env)
+	if err := ioutil.WriteFile(filepath.Join(dir, "metadata.libsonnet"), []byte(content), 0644); err != nil {
+		cleanup()
+		return nil, nil, err
+	}
+
+	opt := func(jt *JsonnetTemplator) {
+		jt.jpaths = append([]string{dir}, jt.jpaths...)
+	}
+
+	return opt, cleanup, nil
+}
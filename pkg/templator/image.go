@@ -0,0 +1,85 @@
+package templator
+
+import (
+	"fmt"
+
+	"github.com/google/go-containerregistry/pkg/name"
+)
+
+// defaultRegistry is the registry host Docker Hub references display
+// as; go-containerregistry normalizes it internally to
+// "index.docker.io", but registry.go's cache key and ImageName.String
+// use the shorter "docker.io" form users actually type.
+const defaultRegistry = "docker.io"
+
+// ImageName is a parsed container image reference, e.g.
+// "gcr.io/project/image:tag" or "redis@sha256:...".
+type ImageName struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+// ParseImageName parses a docker-style image reference, filling in
+// the docker.io registry and library/ repository prefix Docker Hub
+// assumes when they're omitted. References that already carry a
+// digest are parsed as-is. Parsing is delegated to
+// github.com/google/go-containerregistry/pkg/name, which implements
+// the full docker reference grammar (distinguishing a registry port
+// from a tag, Hub's implicit library/ namespace, etc.) rather than
+// reimplementing it here.
+func ParseImageName(ref string) (*ImageName, error) {
+	if ref == "" {
+		return nil, fmt.Errorf("empty image reference")
+	}
+
+	parsed, err := name.ParseReference(ref, name.WithDefaultRegistry(defaultRegistry))
+	if err != nil {
+		return nil, err
+	}
+
+	repo := parsed.Context()
+	registry := repo.RegistryStr()
+	if registry == name.DefaultRegistry {
+		registry = defaultRegistry
+	}
+
+	n := &ImageName{
+		Registry:   registry,
+		Repository: repo.RepositoryStr(),
+	}
+
+	switch r := parsed.(type) {
+	case name.Tag:
+		n.Tag = r.TagStr()
+	case name.Digest:
+		n.Digest = r.DigestStr()
+	}
+
+	return n, nil
+}
+
+// String returns the canonical form of the image reference. A digest
+// takes precedence over the tag when both are set.
+func (n *ImageName) String() string {
+	if n.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", n.Registry, n.Repository, n.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", n.Registry, n.Repository, n.Tag)
+}
+
+// Resolver resolves an ImageName's tag to a content digest, mutating
+// it in place.
+type Resolver interface {
+	Resolve(*ImageName) error
+}
+
+// IdentityResolver is the default Resolver: it leaves the image
+// reference unchanged, preserving the tag as-is.
+type IdentityResolver struct{}
+
+// Resolve implements Resolver.
+func (IdentityResolver) Resolve(*ImageName) error {
+	return nil
+}
@@ -0,0 +1,76 @@
+package templator
+
+import "testing"
+
+const digest64 = "deadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeefdeadbeef"
+
+func TestParseImageName(t *testing.T) {
+	cases := []struct {
+		ref  string
+		want ImageName
+	}{
+		{
+			ref:  "redis",
+			want: ImageName{Registry: "docker.io", Repository: "library/redis", Tag: "latest"},
+		},
+		{
+			ref:  "redis:7",
+			want: ImageName{Registry: "docker.io", Repository: "library/redis", Tag: "7"},
+		},
+		{
+			ref:  "library/redis:7",
+			want: ImageName{Registry: "docker.io", Repository: "library/redis", Tag: "7"},
+		},
+		{
+			ref:  "gcr.io/project/image:tag",
+			want: ImageName{Registry: "gcr.io", Repository: "project/image", Tag: "tag"},
+		},
+		{
+			ref:  "gcr.io/project/image",
+			want: ImageName{Registry: "gcr.io", Repository: "project/image", Tag: "latest"},
+		},
+		{
+			// A registry port must not be mistaken for a tag delimiter.
+			ref:  "localhost:5000/myrepo:tag",
+			want: ImageName{Registry: "localhost:5000", Repository: "myrepo", Tag: "tag"},
+		},
+		{
+			ref:  "localhost:5000/myrepo",
+			want: ImageName{Registry: "localhost:5000", Repository: "myrepo", Tag: "latest"},
+		},
+		{
+			ref:  "redis@sha256:" + digest64,
+			want: ImageName{Registry: "docker.io", Repository: "library/redis", Digest: "sha256:" + digest64},
+		},
+		{
+			ref:  "myregistry.example.com/foo/bar:v1",
+			want: ImageName{Registry: "myregistry.example.com", Repository: "foo/bar", Tag: "v1"},
+		},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.ref, func(t *testing.T) {
+			got, err := ParseImageName(tc.ref)
+			if err != nil {
+				t.Fatalf("ParseImageName(%q): %v", tc.ref, err)
+			}
+			if *got != tc.want {
+				t.Fatalf("ParseImageName(%q) = %+v, want %+v", tc.ref, *got, tc.want)
+			}
+		})
+	}
+}
+
+func TestParseImageNameEmpty(t *testing.T) {
+	if _, err := ParseImageName(""); err == nil {
+		t.Fatal("expected an error for an empty reference")
+	}
+}
+
+func TestImageNameStringPrefersDigestOverTag(t *testing.T) {
+	n := &ImageName{Registry: "docker.io", Repository: "library/redis", Tag: "latest", Digest: "sha256:abc"}
+	want := "docker.io/library/redis@sha256:abc"
+	if got := n.String(); got != want {
+		t.Fatalf("String() = %q, want %q", got, want)
+	}
+}
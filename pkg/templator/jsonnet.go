@@ -6,15 +6,13 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"regexp"
 	"strings"
 
 	goyaml "github.com/ghodss/yaml"
-	jsonnet "github.com/google/go-jsonnet"
-	"github.com/google/go-jsonnet/ast"
+	jsonnetimpl "github.com/gouthamve/haiku/pkg/jsonnet"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/util/yaml"
@@ -26,45 +24,84 @@ var (
 
 // JsonnetTemplator is a jsonnet based templator.
 type JsonnetTemplator struct {
-	vm *jsonnet.VM
+	impl     jsonnetimpl.Implementation
+	jpaths   []string
+	resolver Resolver
 
 	file string
 }
 
-// NewJsonnetTemplator returns a new Templator backed by jsonnet.
-func NewJsonnetTemplator(path string) (*JsonnetTemplator, error) {
+// NewJsonnetTemplator returns a new Templator backed by the default
+// go-jsonnet implementation. opts are applied before the native
+// functions are registered, so they can be used to bind ext vars/code,
+// extend the import path, set the image Resolver, or otherwise
+// configure the templator ahead of evaluation; see WithExtVar and
+// friends.
+func NewJsonnetTemplator(path string, opts ...Opt) (*JsonnetTemplator, error) {
+	return NewJsonnetTemplatorWithSelector(path, "", opts...)
+}
+
+// NewJsonnetTemplatorWithSelector is like NewJsonnetTemplator, but
+// resolves the jsonnet implementation from sel, as accepted by
+// jsonnet.New: "" or "go" for the default go-jsonnet implementation,
+// "binary:/path/to/jsonnet" to shell out to an external jsonnet
+// binary instead.
+func NewJsonnetTemplatorWithSelector(path, sel string, opts ...Opt) (*JsonnetTemplator, error) {
+	impl, err := jsonnetimpl.New(sel)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewJsonnetTemplatorWithImpl(path, impl, opts...)
+}
+
+// NewJsonnetTemplatorWithImpl is like NewJsonnetTemplator, but
+// evaluates through impl instead of the default go-jsonnet
+// implementation, e.g. binaryimpl.New to shell out to an external
+// jsonnet binary.
+func NewJsonnetTemplatorWithImpl(path string, impl jsonnetimpl.Implementation, opts ...Opt) (*JsonnetTemplator, error) {
 	jpaths, file, err := getJPathsAndFile(path)
 	if err != nil {
 		return nil, err
 	}
 
-	vm := jsonnet.MakeVM()
-	importer := jsonnet.FileImporter{
-		JPaths: jpaths,
+	jt := &JsonnetTemplator{
+		impl:     impl,
+		jpaths:   jpaths,
+		resolver: IdentityResolver{},
+		file:     file,
 	}
 
-	vm.Importer(&importer)
-	RegisterNativeFuncs(vm)
+	for _, opt := range opts {
+		opt(jt)
+	}
 
-	return &JsonnetTemplator{
-		vm:   vm,
-		file: file,
-	}, nil
+	jt.impl.Importer(jt.jpaths)
+	RegisterNativeFuncs(jt.impl, &jt.resolver)
+
+	return jt, nil
 }
 
 // Template implements Templator.
 func (jt *JsonnetTemplator) Template() ([]*unstructured.Unstructured, error) {
-	jsonnetBytes, err := ioutil.ReadFile(jt.file)
+	jsonstr, err := jt.impl.EvaluateFile(jt.file)
 	if err != nil {
 		return nil, err
 	}
 
-	jsonstr, err := jt.vm.EvaluateSnippet(jt.file, string(jsonnetBytes))
-	if err != nil {
-		return nil, err
+	return jsonTok8sObjs(jsonstr)
+}
+
+// TemplateWith applies opts to the templator and evaluates it,
+// letting callers bind environment-specific ext vars/TLAs at call
+// time without constructing a new JsonnetTemplator.
+func (jt *JsonnetTemplator) TemplateWith(opts ...Opt) ([]*unstructured.Unstructured, error) {
+	for _, opt := range opts {
+		opt(jt)
 	}
+	jt.impl.Importer(jt.jpaths)
 
-	return jsonTok8sObjs(jsonstr)
+	return jt.Template()
 }
 
 func getJPathsAndFile(path string) ([]string, string, error) {
@@ -105,6 +142,14 @@ func jsonTok8sObjs(jsonstr string) ([]*unstructured.Unstructured, error) {
 		return nil, err
 	}
 
+	return objsFromTop(top)
+}
+
+// objsFromTop walks a decoded JSON/YAML/jsonnet document for k8s
+// objects, flattens them to v1.Unstructured and sorts them for apply.
+// It's the common tail of the json, yaml and jsonnet code paths in
+// Read.
+func objsFromTop(top interface{}) ([]*unstructured.Unstructured, error) {
 	objs, err := jsonWalk(top)
 	if err != nil {
 		return nil, err
@@ -124,112 +169,99 @@ func jsonTok8sObjs(jsonstr string) ([]*unstructured.Unstructured, error) {
 		ret = append(ret, obj)
 	}
 
-	return FlattenToV1(ret), nil
+	return SortForApply(FlattenToV1(ret)), nil
 }
 
-// RegisterNativeFuncs adds kubecfg's native jsonnet functions to provided VM
-func RegisterNativeFuncs(vm *jsonnet.VM) {
+// RegisterNativeFuncs adds kubecfg's native jsonnet functions to impl.
+// resolver is read at call time through the pointer, rather than
+// captured by value, so later changes (e.g. WithResolver applied via
+// TemplateWith) take effect without re-registering natives.
+func RegisterNativeFuncs(impl jsonnetimpl.Implementation, resolver *Resolver) {
 	// NB: libjsonnet native functions can only pass primitive
 	// types, so some functions json-encode the arg.  These
 	// "*FromJson" functions will be replaced by regular native
 	// version when libjsonnet is able to support this.
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "parseJson",
-			Params: ast.Identifiers{"json"},
-			Func: func(dataString []interface{}) (res interface{}, err error) {
-				data := []byte(dataString[0].(string))
-				err = json.Unmarshal(data, &res)
-				return
-			},
+	impl.NativeFunction("parseJson", []string{"json"},
+		func(dataString []interface{}) (res interface{}, err error) {
+			data := []byte(dataString[0].(string))
+			err = json.Unmarshal(data, &res)
+			return
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "parseYaml",
-			Params: ast.Identifiers{"yaml"},
-			Func: func(dataString []interface{}) (interface{}, error) {
-				data := []byte(dataString[0].(string))
-				ret := []interface{}{}
-				d := yaml.NewYAMLToJSONDecoder(bytes.NewReader(data))
-				for {
-					var doc interface{}
-					if err := d.Decode(&doc); err != nil {
-						if err == io.EOF {
-							break
-						}
-						return nil, err
+	impl.NativeFunction("parseYaml", []string{"yaml"},
+		func(dataString []interface{}) (interface{}, error) {
+			data := []byte(dataString[0].(string))
+			ret := []interface{}{}
+			d := yaml.NewYAMLToJSONDecoder(bytes.NewReader(data))
+			for {
+				var doc interface{}
+				if err := d.Decode(&doc); err != nil {
+					if err == io.EOF {
+						break
 					}
-					ret = append(ret, doc)
+					return nil, err
 				}
-				return ret, nil
-			},
+				ret = append(ret, doc)
+			}
+			return ret, nil
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "manifestJsonFromJson",
-			Params: ast.Identifiers{"json", "indent"},
-			Func: func(data []interface{}) (interface{}, error) {
-				indent := int(data[1].(float64))
-				dataBytes := []byte(data[0].(string))
-				dataBytes = bytes.TrimSpace(dataBytes)
-				buf := bytes.Buffer{}
-				if err := json.Indent(&buf, dataBytes, "", strings.Repeat(" ", indent)); err != nil {
-					return "", err
-				}
-				buf.WriteString("\n")
-				return buf.String(), nil
-			},
+	impl.NativeFunction("manifestJsonFromJson", []string{"json", "indent"},
+		func(data []interface{}) (interface{}, error) {
+			indent := int(data[1].(float64))
+			dataBytes := []byte(data[0].(string))
+			dataBytes = bytes.TrimSpace(dataBytes)
+			buf := bytes.Buffer{}
+			if err := json.Indent(&buf, dataBytes, "", strings.Repeat(" ", indent)); err != nil {
+				return "", err
+			}
+			buf.WriteString("\n")
+			return buf.String(), nil
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "manifestYamlFromJson",
-			Params: ast.Identifiers{"json"},
-			Func: func(data []interface{}) (interface{}, error) {
-				var input interface{}
-				dataBytes := []byte(data[0].(string))
-				if err := json.Unmarshal(dataBytes, &input); err != nil {
-					return "", err
-				}
-				output, err := goyaml.Marshal(input)
-				return string(output), err
-			},
+	impl.NativeFunction("manifestYamlFromJson", []string{"json"},
+		func(data []interface{}) (interface{}, error) {
+			var input interface{}
+			dataBytes := []byte(data[0].(string))
+			if err := json.Unmarshal(dataBytes, &input); err != nil {
+				return "", err
+			}
+			output, err := goyaml.Marshal(input)
+			return string(output), err
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "escapeStringRegex",
-			Params: ast.Identifiers{"str"},
-			Func: func(s []interface{}) (interface{}, error) {
-				return regexp.QuoteMeta(s[0].(string)), nil
-			},
+	impl.NativeFunction("escapeStringRegex", []string{"str"},
+		func(s []interface{}) (interface{}, error) {
+			return regexp.QuoteMeta(s[0].(string)), nil
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "regexMatch",
-			Params: ast.Identifiers{"regex", "string"},
-			Func: func(s []interface{}) (interface{}, error) {
-				return regexp.MatchString(s[0].(string), s[1].(string))
-			},
+	impl.NativeFunction("regexMatch", []string{"regex", "string"},
+		func(s []interface{}) (interface{}, error) {
+			return regexp.MatchString(s[0].(string), s[1].(string))
 		})
 
-	vm.NativeFunction(
-		&jsonnet.NativeFunction{
-			Name:   "regexSubst",
-			Params: ast.Identifiers{"regex", "src", "repl"},
-			Func: func(data []interface{}) (interface{}, error) {
-				regex, src, repl := data[0].(string), data[1].(string), data[2].(string)
+	impl.NativeFunction("regexSubst", []string{"regex", "src", "repl"},
+		func(data []interface{}) (interface{}, error) {
+			regex, src, repl := data[0].(string), data[1].(string), data[2].(string)
 
-				r, err := regexp.Compile(regex)
-				if err != nil {
-					return "", err
-				}
-				return r.ReplaceAllString(src, repl), nil
-			},
+			r, err := regexp.Compile(regex)
+			if err != nil {
+				return "", err
+			}
+			return r.ReplaceAllString(src, repl), nil
+		})
+
+	impl.NativeFunction("resolveImage", []string{"image"},
+		func(data []interface{}) (interface{}, error) {
+			name, err := ParseImageName(data[0].(string))
+			if err != nil {
+				return nil, err
+			}
+			if err := (*resolver).Resolve(name); err != nil {
+				return nil, err
+			}
+			return name.String(), nil
 		})
 }
 
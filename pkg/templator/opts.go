@@ -0,0 +1,95 @@
+package templator
+
+import (
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"github.com/gouthamve/haiku/pkg/jsonnet/goimpl"
+)
+
+// Opt configures a JsonnetTemplator, either at construction time or
+// via TemplateWith.
+type Opt func(*JsonnetTemplator)
+
+// WithExtVar binds an external variable to a string value, equivalent
+// to jsonnet's --ext-str.
+func WithExtVar(k, v string) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.impl.ExtVar(k, v)
+	}
+}
+
+// WithExtCode binds an external variable to a jsonnet expression,
+// equivalent to jsonnet's --ext-code.
+func WithExtCode(k, code string) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.impl.ExtCode(k, code)
+	}
+}
+
+// WithTLAVar binds a top-level argument to a string value, equivalent
+// to jsonnet's --tla-str.
+func WithTLAVar(k, v string) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.impl.TLAVar(k, v)
+	}
+}
+
+// WithTLACode binds a top-level argument to a jsonnet expression,
+// equivalent to jsonnet's --tla-code.
+func WithTLACode(k, code string) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.impl.TLACode(k, code)
+	}
+}
+
+// WithMaxStack sets the maximum jsonnet evaluation stack depth. It
+// only has an effect when the templator is using the default
+// go-jsonnet implementation.
+func WithMaxStack(n int) Opt {
+	return func(jt *JsonnetTemplator) {
+		if g, ok := jt.impl.(*goimpl.Impl); ok {
+			g.SetMaxStack(n)
+		}
+	}
+}
+
+// WithImportPath appends p to the templator's jsonnet import path.
+func WithImportPath(p string) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.jpaths = append(jt.jpaths, p)
+	}
+}
+
+// WithResolver sets the Resolver used to back the resolveImage native
+// function. Defaults to IdentityResolver, which leaves image
+// references untouched; pass NewRegistryResolver() to resolve tags to
+// digests against the source registry.
+func WithResolver(r Resolver) Opt {
+	return func(jt *JsonnetTemplator) {
+		jt.resolver = r
+	}
+}
+
+// ParseVar parses a "key=value" or "key=@file" command line argument,
+// the form used by jsonnet/tanka/kubecfg-style --ext-str and
+// --tla-str flags. In the "@file" form, value is read from the file
+// at the given path.
+func ParseVar(s string) (key, value string, err error) {
+	parts := strings.SplitN(s, "=", 2)
+	if len(parts) != 2 {
+		return "", "", fmt.Errorf("invalid variable %q, expected key=value or key=@file", s)
+	}
+
+	key, value = parts[0], parts[1]
+	if strings.HasPrefix(value, "@") {
+		data, err := ioutil.ReadFile(value[1:])
+		if err != nil {
+			return "", "", err
+		}
+		value = string(data)
+	}
+
+	return key, value, nil
+}
@@ -0,0 +1,177 @@
+package templator
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+
+	jsonnetimpl "github.com/gouthamve/haiku/pkg/jsonnet"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+var errUnknownExtension = errors.New("unknown file extension")
+
+// Read evaluates path and returns the k8s objects it describes,
+// dispatching on file extension: .json files are decoded as one or
+// more JSON documents, .yaml/.yml files are split and decoded as one
+// or more YAML documents, and .jsonnet/.libsonnet files are evaluated
+// through impl. Directories are walked recursively, skipping files with
+// an unrecognised extension. Passing "-" reads a single stream of
+// YAML (or JSON, which is a YAML subset) from stdin.
+func Read(impl jsonnetimpl.Implementation, path string) ([]*unstructured.Unstructured, error) {
+	if path == "-" {
+		return decodeYAMLDocs(os.Stdin)
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if !info.IsDir() {
+		objs, err := readFile(impl, path)
+		if err == errUnknownExtension {
+			return nil, nil
+		}
+		return objs, err
+	}
+
+	var ret []*unstructured.Unstructured
+	err = filepath.Walk(path, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+
+		objs, err := readFile(impl, p)
+		if err == errUnknownExtension {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		ret = append(ret, objs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return ret, nil
+}
+
+// readFile evaluates a single file, dispatching on its extension.
+func readFile(impl jsonnetimpl.Implementation, path string) ([]*unstructured.Unstructured, error) {
+	switch filepath.Ext(path) {
+	case ".json":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return decodeJSONDocs(f)
+
+	case ".yaml", ".yml":
+		f, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+		defer f.Close()
+		return decodeYAMLDocs(f)
+
+	case ".jsonnet", ".libsonnet":
+		jsonstr, err := impl.EvaluateFile(path)
+		if err != nil {
+			return nil, err
+		}
+		return jsonTok8sObjs(jsonstr)
+
+	default:
+		return nil, errUnknownExtension
+	}
+}
+
+// decodeJSONDocs decodes a stream of concatenated JSON documents from
+// r, funnelling each through the jsonWalk/FlattenToV1 pipeline.
+func decodeJSONDocs(r io.Reader) ([]*unstructured.Unstructured, error) {
+	dec := json.NewDecoder(r)
+
+	var ret []*unstructured.Unstructured
+	for dec.More() {
+		var top interface{}
+		if err := dec.Decode(&top); err != nil {
+			return nil, err
+		}
+
+		objs, err := objsFromTop(top)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, objs...)
+	}
+
+	return ret, nil
+}
+
+// decodeYAMLDocs splits r into individual YAML documents and decodes
+// each, funnelling them through the jsonWalk/FlattenToV1 pipeline.
+func decodeYAMLDocs(r io.Reader) ([]*unstructured.Unstructured, error) {
+	reader := yaml.NewYAMLReader(bufio.NewReader(r))
+
+	var ret []*unstructured.Unstructured
+	for {
+		doc, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		jsonBytes, err := yaml.ToJSON(doc)
+		if err != nil {
+			return nil, err
+		}
+		if len(jsonBytes) == 0 || string(jsonBytes) == "null" {
+			continue
+		}
+
+		var top interface{}
+		if err := json.Unmarshal(jsonBytes, &top); err != nil {
+			return nil, err
+		}
+
+		objs, err := objsFromTop(top)
+		if err != nil {
+			return nil, err
+		}
+		ret = append(ret, objs...)
+	}
+
+	return ret, nil
+}
+
+// ReadTemplator is a Templator that dispatches on file extension via
+// Read, so callers can point it at a directory of mixed json, yaml
+// and jsonnet manifests.
+type ReadTemplator struct {
+	impl jsonnetimpl.Implementation
+	path string
+}
+
+// NewReadTemplator returns a ReadTemplator for path, evaluating any
+// jsonnet it encounters through impl.
+func NewReadTemplator(impl jsonnetimpl.Implementation, path string) *ReadTemplator {
+	return &ReadTemplator{impl: impl, path: path}
+}
+
+// Template implements Templator.
+func (rt *ReadTemplator) Template() ([]*unstructured.Unstructured, error) {
+	return Read(rt.impl, rt.path)
+}
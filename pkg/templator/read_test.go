@@ -0,0 +1,96 @@
+package templator
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gouthamve/haiku/pkg/jsonnet/goimpl"
+)
+
+func writeFile(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestReadDispatchesOnExtension(t *testing.T) {
+	dir := t.TempDir()
+	impl := goimpl.New()
+
+	cases := []struct {
+		name    string
+		content string
+	}{
+		{"pod.json", `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a"}}`},
+		{"pod.yaml", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: b\n"},
+		{"pod.jsonnet", `{apiVersion: "v1", kind: "Pod", metadata: {name: "c"}}`},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			path := writeFile(t, dir, tc.name, tc.content)
+			objs, err := Read(impl, path)
+			if err != nil {
+				t.Fatalf("Read(%s): %v", tc.name, err)
+			}
+			if len(objs) != 1 || objs[0].GetKind() != "Pod" {
+				t.Fatalf("Read(%s) = %+v, want a single Pod", tc.name, objs)
+			}
+		})
+	}
+}
+
+func TestReadUnknownExtensionIsSkipped(t *testing.T) {
+	dir := t.TempDir()
+	path := writeFile(t, dir, "README.md", "not a manifest")
+
+	objs, err := Read(goimpl.New(), path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if objs != nil {
+		t.Fatalf("Read(README.md) = %+v, want nil", objs)
+	}
+}
+
+func TestReadWalksDirectories(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "a.json", `{"apiVersion":"v1","kind":"Pod","metadata":{"name":"a"}}`)
+	writeFile(t, dir, "b.yaml", "apiVersion: v1\nkind: Pod\nmetadata:\n  name: b\n")
+	writeFile(t, dir, "README.md", "ignored")
+
+	objs, err := Read(goimpl.New(), dir)
+	if err != nil {
+		t.Fatalf("Read(dir): %v", err)
+	}
+	if len(objs) != 2 {
+		t.Fatalf("Read(dir) returned %d objects, want 2 (README.md should be skipped)", len(objs))
+	}
+}
+
+func TestReadStdin(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := os.Stdin
+	os.Stdin = r
+	defer func() { os.Stdin = old }()
+
+	go func() {
+		w.Write([]byte("apiVersion: v1\nkind: Pod\nmetadata:\n  name: stdin\n"))
+		w.Close()
+	}()
+
+	objs, err := Read(goimpl.New(), "-")
+	if err != nil {
+		t.Fatalf("Read(-): %v", err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "stdin" {
+		t.Fatalf("Read(-) = %+v, want a single Pod named stdin", objs)
+	}
+}
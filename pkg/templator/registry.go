@@ -0,0 +1,64 @@
+package templator
+
+import (
+	"sync"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+)
+
+// RegistryResolver resolves images against their source container
+// registry, using the local docker config for auth. Results are
+// cached in-memory, keyed by repo:tag, for the lifetime of the
+// resolver. Safe for concurrent use, so a single RegistryResolver can
+// be shared across environments (e.g. via EnvironmentSet) to get
+// cache reuse across them.
+type RegistryResolver struct {
+	mu    sync.Mutex
+	cache map[string]string
+}
+
+// NewRegistryResolver returns a Resolver backed by the container
+// registries referenced by the images it resolves.
+func NewRegistryResolver() *RegistryResolver {
+	return &RegistryResolver{cache: map[string]string{}}
+}
+
+// Resolve implements Resolver. Images that already carry a digest are
+// left unchanged.
+func (r *RegistryResolver) Resolve(n *ImageName) error {
+	if n.Digest != "" {
+		return nil
+	}
+
+	key := n.Registry + "/" + n.Repository + ":" + n.Tag
+
+	r.mu.Lock()
+	digest, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok {
+		n.Digest = digest
+		n.Tag = ""
+		return nil
+	}
+
+	ref, err := name.ParseReference(key)
+	if err != nil {
+		return err
+	}
+
+	desc, err := remote.Get(ref, remote.WithAuthFromKeychain(authn.DefaultKeychain))
+	if err != nil {
+		return err
+	}
+
+	n.Digest = desc.Digest.String()
+	n.Tag = ""
+
+	r.mu.Lock()
+	r.cache[key] = n.Digest
+	r.mu.Unlock()
+
+	return nil
+}
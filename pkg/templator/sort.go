@@ -0,0 +1,77 @@
+package templator
+
+import (
+	"sort"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// kindPriority orders well-known kinds so that the resources other
+// objects depend on are applied first: namespaces and CRDs, then
+// RBAC, then configmaps/secrets, then workloads, then
+// services/ingresses. Kinds not listed here sort lexicographically
+// after every listed kind.
+var kindPriority = map[string]int{
+	"Namespace":                0,
+	"CustomResourceDefinition": 1,
+
+	"ClusterRole":        2,
+	"ClusterRoleBinding": 2,
+	"Role":               2,
+	"RoleBinding":        2,
+	"ServiceAccount":     2,
+
+	"ConfigMap": 3,
+	"Secret":    3,
+
+	"PersistentVolume":      4,
+	"PersistentVolumeClaim": 4,
+
+	"DaemonSet":   5,
+	"Deployment":  5,
+	"StatefulSet": 5,
+	"ReplicaSet":  5,
+	"Job":         5,
+	"CronJob":     5,
+
+	"Service": 6,
+	"Ingress": 6,
+}
+
+// unknownKindPriority sorts after every kind listed in kindPriority.
+const unknownKindPriority = 1 << 30
+
+// SortForApply orders objs for a deterministic, dependency-safe
+// apply. Objects of an unlisted kind are sorted lexicographically by
+// kind, after every known kind; ties (including two unknown kinds of
+// the same kind) are broken by namespace/name.
+func SortForApply(objs []*unstructured.Unstructured) []*unstructured.Unstructured {
+	sorted := make([]*unstructured.Unstructured, len(objs))
+	copy(sorted, objs)
+
+	sort.SliceStable(sorted, func(i, j int) bool {
+		pi, pj := kindPriorityFor(sorted[i]), kindPriorityFor(sorted[j])
+		if pi != pj {
+			return pi < pj
+		}
+
+		if ki, kj := sorted[i].GetKind(), sorted[j].GetKind(); ki != kj {
+			return ki < kj
+		}
+
+		return namespacedName(sorted[i]) < namespacedName(sorted[j])
+	})
+
+	return sorted
+}
+
+func kindPriorityFor(obj *unstructured.Unstructured) int {
+	if p, ok := kindPriority[obj.GetKind()]; ok {
+		return p
+	}
+	return unknownKindPriority
+}
+
+func namespacedName(obj *unstructured.Unstructured) string {
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
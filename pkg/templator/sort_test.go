@@ -0,0 +1,81 @@
+package templator
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func obj(kind, namespace, name string) *unstructured.Unstructured {
+	u := &unstructured.Unstructured{}
+	u.SetKind(kind)
+	u.SetNamespace(namespace)
+	u.SetName(name)
+	return u
+}
+
+func kinds(objs []*unstructured.Unstructured) []string {
+	ret := make([]string, len(objs))
+	for i, o := range objs {
+		ret[i] = o.GetKind()
+	}
+	return ret
+}
+
+func TestSortForApplyCRDBeforeCR(t *testing.T) {
+	in := []*unstructured.Unstructured{
+		obj("Prometheus", "monitoring", "main"),
+		obj("CustomResourceDefinition", "", "prometheuses.monitoring.coreos.com"),
+	}
+
+	got := kinds(SortForApply(in))
+	want := []string{"CustomResourceDefinition", "Prometheus"}
+
+	assertKindsEqual(t, got, want)
+}
+
+func TestSortForApplyNamespaceBeforeNamespacedObject(t *testing.T) {
+	in := []*unstructured.Unstructured{
+		obj("ConfigMap", "monitoring", "config"),
+		obj("Namespace", "", "monitoring"),
+	}
+
+	got := kinds(SortForApply(in))
+	want := []string{"Namespace", "ConfigMap"}
+
+	assertKindsEqual(t, got, want)
+}
+
+func TestSortForApplyIsStableAndDeterministic(t *testing.T) {
+	in := []*unstructured.Unstructured{
+		obj("Deployment", "default", "b"),
+		obj("Service", "default", "svc"),
+		obj("Deployment", "default", "a"),
+		obj("Secret", "default", "creds"),
+	}
+
+	sorted := SortForApply(in)
+	got := kinds(sorted)
+	want := []string{"Secret", "Deployment", "Deployment", "Service"}
+
+	assertKindsEqual(t, got, want)
+
+	// The two Deployments tie on kind and are ordered by namespace/name,
+	// not by their input order.
+	if a, b := sorted[1].GetName(), sorted[2].GetName(); a != "a" || b != "b" {
+		t.Fatalf("Deployments ordered %q, %q; want \"a\", \"b\"", a, b)
+	}
+}
+
+func assertKindsEqual(t *testing.T, got, want []string) {
+	t.Helper()
+
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}